@@ -16,7 +16,7 @@ package bodyparser
 
 import (
 	"bytes"
-	"compress/gzip"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -42,34 +42,66 @@ type (
 	Decode func(c *elton.Context, originalData []byte) (data []byte, err error)
 	// Validate body content type check validate function
 	Validate func(c *elton.Context) bool
+	// Validator post-decode validation hook, run against the decoded body
+	// once decoding succeeds, before calling c.Next()
+	Validator func(c *elton.Context, decoded []byte) error
 	// Decoder decoder
 	Decoder struct {
 		Decode   Decode
 		Validate Validate
+		// Encoding is the Content-Encoding token this decoder handles
+		// (eg: "gzip"), used to chain multiple encoding decoders applied to
+		// the same request (eg: `Content-Encoding: gzip, br`) in the
+		// correct(reverse) order. Content type based decoders(json, form)
+		// leave this empty.
+		Encoding string
 	}
+	// ContentTypeDecode decode function registered for a specific content type,
+	// the decoded value is kept as is (not forced back to []byte) so that
+	// structured formats (xml, msgpack, multipart/form-data) don't need a
+	// lossy byte round trip
+	ContentTypeDecode func(c *elton.Context, originalData []byte) (data interface{}, err error)
 	// Config json parser config
 	Config struct {
 		// Limit the limit size of body
 		Limit int
-		// Decoders decode list
-		Decoders            []*Decoder
+		// DecompressedLimit the limit size of the decompressed body, checked
+		// while streaming through the decompressor so a small compressed
+		// payload can't exhaust memory by decompressing to something huge
+		// (eg: a gzip bomb)
+		DecompressedLimit int
+		// Decoders decode list, checked before ContentTypeDecoders: the
+		// decoders matching the Content-Encoding chain (eg: gzip) are
+		// applied, otherwise the first decoder in order whose Validate passes
+		Decoders []*Decoder
+		// ContentTypeDecoders decode function keyed by content type(without
+		// the `;charset=...` parameters), dispatched in O(1), used only when
+		// no decoder of Decoders matched
+		ContentTypeDecoders map[string]ContentTypeDecode
 		Skipper             elton.Skipper
 		ContentTypeValidate Validate
+		// Validator runs after the body has been decoded, rejecting the
+		// request before c.Next() if it returns an error
+		Validator Validator
 	}
 )
 
+const (
+	// decodedBodyContextKey is the context key used to store the value
+	// decoded by a ContentTypeDecode, so BindBody and downstream middlewares
+	// don't need to decode the body again
+	decodedBodyContextKey = "bodyParserDecodedBody"
+	// decompressedLimitContextKey is the context key used to pass
+	// Config.DecompressedLimit down to the streaming decompression decoders
+	decompressedLimitContextKey = "bodyParserDecompressedLimit"
+)
+
 var (
 	validMethods = []string{
 		http.MethodPost,
 		http.MethodPatch,
 		http.MethodPut,
 	}
-	errInvalidJSON = &hes.Error{
-		Category:   ErrCategory,
-		Message:    "invalid json format",
-		StatusCode: http.StatusBadRequest,
-	}
-	jsonBytes = []byte("{}[]")
 )
 
 // AddDecoder add decoder
@@ -80,51 +112,14 @@ func (conf *Config) AddDecoder(decoder *Decoder) {
 	conf.Decoders = append(conf.Decoders, decoder)
 }
 
-// NewGzipDecoder new gzip decoder
-func NewGzipDecoder() *Decoder {
-	return &Decoder{
-		Validate: func(c *elton.Context) bool {
-			encoding := c.GetRequestHeader(elton.HeaderContentEncoding)
-			return encoding == elton.Gzip
-		},
-		Decode: func(c *elton.Context, originalData []byte) (data []byte, err error) {
-			c.SetRequestHeader(elton.HeaderContentEncoding, "")
-			return doGunzip(originalData)
-		},
-	}
-}
-
-// NewJSONDecoder new json decoder
-func NewJSONDecoder() *Decoder {
-	return &Decoder{
-		Validate: func(c *elton.Context) bool {
-			ct := c.GetRequestHeader(elton.HeaderContentType)
-			ctFields := strings.Split(ct, ";")
-			return ctFields[0] == jsonContentType
-		},
-		Decode: func(c *elton.Context, originalData []byte) (data []byte, err error) {
-			originalData = bytes.TrimSpace(originalData)
-			if len(originalData) == 0 {
-				return nil, nil
-			}
-			firstByte := originalData[0]
-			lastByte := originalData[len(originalData)-1]
-
-			if firstByte != jsonBytes[0] && firstByte != jsonBytes[2] {
-				err = errInvalidJSON
-				return
-			}
-			if firstByte == jsonBytes[0] && lastByte != jsonBytes[1] {
-				err = errInvalidJSON
-				return
-			}
-			if firstByte == jsonBytes[2] && lastByte != jsonBytes[3] {
-				err = errInvalidJSON
-				return
-			}
-			return originalData, nil
-		},
+// RegisterDecoder register a decoder for the given content type(eg: `application/xml`),
+// the content type is matched after stripping any `;charset=...` style parameters.
+// Registering the same content type twice overwrites the previous decoder.
+func (conf *Config) RegisterDecoder(contentType string, fn ContentTypeDecode) {
+	if conf.ContentTypeDecoders == nil {
+		conf.ContentTypeDecoders = make(map[string]ContentTypeDecode)
 	}
+	conf.ContentTypeDecoders[contentType] = fn
 }
 
 // NewFormURLEncodedDecoder new form url encode decoder
@@ -161,6 +156,55 @@ func NewFormURLEncodedDecoder() *Decoder {
 	}
 }
 
+// getContentType returns the request's content type with any `;charset=...`
+// style parameters stripped, ready to use as a ContentTypeDecoders key
+func getContentType(c *elton.Context) string {
+	ct := c.GetRequestHeader(elton.HeaderContentType)
+	if index := strings.IndexByte(ct, ';'); index != -1 {
+		ct = ct[:index]
+	}
+	return strings.TrimSpace(ct)
+}
+
+// BindBody binds the request body to v, using the value decoded by the
+// ContentTypeDecode registered for the request's content type. It should be
+// called after the body parser middleware, once the decoder has populated
+// the decoded body on the context.
+func BindBody(c *elton.Context, v interface{}) error {
+	data, ok := c.Get(decodedBodyContextKey)
+	if !ok {
+		return &hes.Error{
+			Category:   ErrCategory,
+			Message:    "no decoded body found, the content type may not be registered",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	// round trip through json so that any decoded value(map, struct, slice)
+	// can be bound into the caller supplied struct
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return &hes.Error{
+			Category:   ErrCategory,
+			Message:    err.Error(),
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	if disallow, _ := c.Get(disallowUnknownFieldsContextKey); disallow == true {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(v); err != nil {
+		return &hes.Error{
+			Category:   ErrCategory,
+			Message:    err.Error(),
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+	}
+	return nil
+}
+
 // DefaultJSONContentTypeValidate default json content type validate
 func DefaultJSONContentTypeValidate(c *elton.Context) bool {
 	ct := c.GetRequestHeader(elton.HeaderContentType)
@@ -184,16 +228,6 @@ func NewDefault() elton.Handler {
 	return New(conf)
 }
 
-// doGunzip gunzip
-func doGunzip(buf []byte) ([]byte, error) {
-	r, err := gzip.NewReader(bytes.NewBuffer(buf))
-	if err != nil {
-		return nil, err
-	}
-	defer r.Close()
-	return ioutil.ReadAll(r)
-}
-
 type maxBytesReader struct {
 	r   io.ReadCloser // underlying reader
 	n   int64         // max bytes remaining
@@ -255,7 +289,10 @@ func New(config Config) elton.Handler {
 		contentTypeValidate = DefaultJSONContentTypeValidate
 	}
 	return func(c *elton.Context) (err error) {
-		if skipper(c) || c.RequestBody != nil || !contentTypeValidate(c) {
+		// 除了ContentTypeValidate允许的类型外，已通过RegisterDecoder注册的
+		// content type也应该被处理，否则RegisterDecoder将形同虚设
+		hasRegisteredContentType := len(config.ContentTypeDecoders) != 0 && config.ContentTypeDecoders[getContentType(c)] != nil
+		if skipper(c) || c.RequestBody != nil || (!contentTypeValidate(c) && !hasRegisteredContentType) {
 			return c.Next()
 		}
 		method := c.Request.Method
@@ -295,26 +332,79 @@ func New(config Config) elton.Handler {
 		}
 		body := c.RequestBody
 
+		if config.DecompressedLimit > 0 {
+			c.Set(decompressedLimitContextKey, config.DecompressedLimit)
+		}
+
 		decodeList := make([]Decode, 0)
-		for _, decoder := range config.Decoders {
-			if decoder.Validate(c) {
-				decodeList = append(decodeList, decoder.Decode)
-				break
+		// Content-Encoding可能为多个编码链式组合（如gzip, br），按声明顺序的
+		// 反序逐个匹配对应的decoder，以还原编码时的组合顺序
+		for _, encoding := range parseContentEncodings(c) {
+			for _, decoder := range config.Decoders {
+				if decoder.Encoding == encoding && decoder.Validate(c) {
+					decodeList = append(decodeList, decoder.Decode)
+					break
+				}
 			}
 		}
-		// 没有符合条件的解码
 		if len(decodeList) == 0 {
+			for _, decoder := range config.Decoders {
+				if decoder.Validate(c) {
+					decodeList = append(decodeList, decoder.Decode)
+					break
+				}
+			}
+		}
+		if len(decodeList) != 0 {
+			for _, decode := range decodeList {
+				body, err = decode(c, body)
+				if err != nil {
+					return
+				}
+			}
+			c.RequestBody = body
+			if config.Validator != nil {
+				if err = config.Validator(c, body); err != nil {
+					return
+				}
+			}
 			return c.Next()
 		}
 
-		for _, decode := range decodeList {
-			body, err = decode(c, body)
-			if err != nil {
-				return
+		// 未匹配encoding decoder时，按content type进行O(1)的解码
+		if len(config.ContentTypeDecoders) != 0 {
+			fn := config.ContentTypeDecoders[getContentType(c)]
+			if fn != nil {
+				var data interface{}
+				data, err = fn(c, body)
+				if err != nil {
+					return
+				}
+				c.Set(decodedBodyContextKey, data)
+				if config.Validator != nil {
+					// Validator expects json bytes(see Decoder path above,
+					// where body is already json), so marshal the decoded
+					// value rather than passing the raw xml/msgpack/multipart
+					// bytes through
+					var validateBuf []byte
+					validateBuf, err = json.Marshal(data)
+					if err != nil {
+						err = &hes.Error{
+							Category:   ErrCategory,
+							Message:    err.Error(),
+							StatusCode: http.StatusBadRequest,
+							Err:        err,
+						}
+						return
+					}
+					if err = config.Validator(c, validateBuf); err != nil {
+						return
+					}
+				}
+				return c.Next()
 			}
 		}
-		c.RequestBody = body
-
+		// 没有符合条件的解码
 		return c.Next()
 	}
 }
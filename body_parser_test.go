@@ -40,51 +40,6 @@ func NewErrorReadCloser(err error) io.ReadCloser {
 	return r
 }
 
-func TestGzipDecoder(t *testing.T) {
-	gzipDecoder := NewGzipDecoder()
-	assert := assert.New(t)
-	originalBuf := []byte("abcdabcdabcd")
-	var b bytes.Buffer
-	w, _ := gzip.NewWriterLevel(&b, 9)
-	_, err := w.Write(originalBuf)
-	assert.Nil(err)
-	w.Close()
-
-	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
-	assert.False(gzipDecoder.Validate(c))
-
-	c.SetRequestHeader(elton.HeaderContentEncoding, elton.Gzip)
-	assert.True(gzipDecoder.Validate(c))
-	buf, err := gzipDecoder.Decode(c, b.Bytes())
-	assert.Nil(err)
-	assert.Equal(originalBuf, buf)
-
-	_, err = gzipDecoder.Decode(c, []byte("ab"))
-	assert.NotNil(err)
-}
-
-func TestJSONDecoder(t *testing.T) {
-	assert := assert.New(t)
-	jsonDecoder := NewJSONDecoder()
-	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
-	assert.False(jsonDecoder.Validate(c))
-	c.SetRequestHeader(elton.HeaderContentType, elton.MIMEApplicationJSON)
-	assert.True(jsonDecoder.Validate(c))
-
-	buf := []byte(`{"a": 1}`)
-	data, err := jsonDecoder.Decode(c, buf)
-	assert.Nil(err)
-	assert.Equal(buf, data)
-	_, err = jsonDecoder.Decode(c, []byte("abcd"))
-	assert.Equal(errInvalidJSON, err)
-
-	_, err = jsonDecoder.Decode(c, []byte("{abcd"))
-	assert.Equal(errInvalidJSON, err)
-
-	_, err = jsonDecoder.Decode(c, []byte("[abcd"))
-	assert.Equal(errInvalidJSON, err)
-}
-
 func TestFormURLEncodedDecoder(t *testing.T) {
 	assert := assert.New(t)
 	formURLEncodedDecoder := NewFormURLEncodedDecoder()
@@ -280,6 +235,39 @@ func TestBodyParser(t *testing.T) {
 		assert.True(done)
 	})
 
+	t.Run("register decoder and bind body", func(t *testing.T) {
+		assert := assert.New(t)
+		conf := Config{}
+		conf.RegisterDecoder("application/xml", NewXMLDecoder())
+		bodyParser := New(conf)
+		body := `<user><name>tree.xie</name></user>`
+		req := httptest.NewRequest("POST", "https://aslant.site/", strings.NewReader(body))
+		req.Header.Set(elton.HeaderContentType, "application/xml; charset=UTF-8")
+		c := elton.NewContext(nil, req)
+		done := false
+		c.Next = func() error {
+			done = true
+			type user struct {
+				User struct {
+					Name struct {
+						Text string `json:"#text"`
+					} `json:"name"`
+				} `json:"user"`
+			}
+			u := user{}
+			if err := BindBody(c, &u); err != nil {
+				return err
+			}
+			if u.User.Name.Text != "tree.xie" {
+				return hes.New("request body is invalid")
+			}
+			return nil
+		}
+		err := bodyParser(c)
+		assert.Nil(err)
+		assert.True(done)
+	})
+
 	t.Run("parse form url encoded success", func(t *testing.T) {
 		assert := assert.New(t)
 		conf := Config{}
@@ -0,0 +1,152 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bodyparser
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/vicanso/elton"
+	"github.com/vicanso/hes"
+)
+
+const (
+	gzipEncoding    = "gzip"
+	deflateEncoding = "deflate"
+	brotliEncoding  = "br"
+)
+
+// parseContentEncodings splits the Content-Encoding header into its
+// comma-separated tokens and reverses them, since the codings are listed in
+// the order they were applied(RFC 7231 3.1.2.2) and must be undone in the
+// opposite order(eg: `gzip, br` was gzip-ed then brotli-ed, so it must be
+// un-brotli-ed then un-gziped).
+func parseContentEncodings(c *elton.Context) []string {
+	encoding := c.GetRequestHeader(elton.HeaderContentEncoding)
+	if encoding == "" {
+		return nil
+	}
+	fields := strings.Split(encoding, ",")
+	tokens := make([]string, 0, len(fields))
+	for _, field := range fields {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field != "" {
+			tokens = append(tokens, field)
+		}
+	}
+	for i, j := 0, len(tokens)-1; i < j; i, j = i+1, j-1 {
+		tokens[i], tokens[j] = tokens[j], tokens[i]
+	}
+	return tokens
+}
+
+// readDecompressed reads r to completion, using the Config.DecompressedLimit
+// stashed on the context(if any) to bound how much is read through the
+// decompressor, so a small compressed payload that expands to something huge
+// (eg: a gzip bomb) is rejected without first decompressing all of it.
+func readDecompressed(c *elton.Context, r io.Reader) ([]byte, error) {
+	v, _ := c.Get(decompressedLimitContextKey)
+	limit, _ := v.(int)
+	if limit <= 0 {
+		return ioutil.ReadAll(r)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > limit {
+		return nil, &hes.Error{
+			Category:   ErrCategory,
+			Message:    fmt.Sprintf("decompressed body is too large, it should be <= %d", limit),
+			StatusCode: http.StatusRequestEntityTooLarge,
+		}
+	}
+	return data, nil
+}
+
+// validateEncoding returns a Validate which checks whether token is present
+// in the request's Content-Encoding chain
+func validateEncoding(token string) Validate {
+	return func(c *elton.Context) bool {
+		for _, encoding := range parseContentEncodings(c) {
+			if encoding == token {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// NewGzipDecoder new gzip decoder
+func NewGzipDecoder() *Decoder {
+	return &Decoder{
+		Encoding: gzipEncoding,
+		Validate: validateEncoding(gzipEncoding),
+		Decode: func(c *elton.Context, originalData []byte) (data []byte, err error) {
+			c.SetRequestHeader(elton.HeaderContentEncoding, "")
+			r, err := gzip.NewReader(bytes.NewReader(originalData))
+			if err != nil {
+				return nil, err
+			}
+			defer r.Close()
+			return readDecompressed(c, r)
+		},
+	}
+}
+
+// NewDeflateDecoder new deflate decoder
+func NewDeflateDecoder() *Decoder {
+	return &Decoder{
+		Encoding: deflateEncoding,
+		Validate: validateEncoding(deflateEncoding),
+		Decode: func(c *elton.Context, originalData []byte) (data []byte, err error) {
+			c.SetRequestHeader(elton.HeaderContentEncoding, "")
+			// `Content-Encoding: deflate` is zlib-wrapped(RFC 1950) for
+			// the vast majority of real-world clients, even though the
+			// coding is named after raw DEFLATE(RFC 1951). Try zlib first
+			// and fall back to raw flate for the minority of clients that
+			// send it unwrapped.
+			r, e := zlib.NewReader(bytes.NewReader(originalData))
+			if e != nil {
+				r := flate.NewReader(bytes.NewReader(originalData))
+				defer r.Close()
+				return readDecompressed(c, r)
+			}
+			defer r.Close()
+			return readDecompressed(c, r)
+		},
+	}
+}
+
+// NewBrotliDecoder new brotli decoder
+func NewBrotliDecoder() *Decoder {
+	return &Decoder{
+		Encoding: brotliEncoding,
+		Validate: validateEncoding(brotliEncoding),
+		Decode: func(c *elton.Context, originalData []byte) (data []byte, err error) {
+			c.SetRequestHeader(elton.HeaderContentEncoding, "")
+			r := brotli.NewReader(bytes.NewReader(originalData))
+			return readDecompressed(c, r)
+		},
+	}
+}
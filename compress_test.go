@@ -0,0 +1,164 @@
+package bodyparser
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+	"github.com/vicanso/hes"
+)
+
+func TestGzipDecoder(t *testing.T) {
+	gzipDecoder := NewGzipDecoder()
+	assert := assert.New(t)
+	originalBuf := []byte("abcdabcdabcd")
+	var b bytes.Buffer
+	w, _ := gzip.NewWriterLevel(&b, 9)
+	_, err := w.Write(originalBuf)
+	assert.Nil(err)
+	w.Close()
+
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.False(gzipDecoder.Validate(c))
+
+	c.SetRequestHeader(elton.HeaderContentEncoding, elton.Gzip)
+	assert.True(gzipDecoder.Validate(c))
+	buf, err := gzipDecoder.Decode(c, b.Bytes())
+	assert.Nil(err)
+	assert.Equal(originalBuf, buf)
+
+	_, err = gzipDecoder.Decode(c, []byte("ab"))
+	assert.NotNil(err)
+}
+
+func TestDeflateDecoder(t *testing.T) {
+	assert := assert.New(t)
+	deflateDecoder := NewDeflateDecoder()
+	originalBuf := []byte("abcdabcdabcd")
+	// real-world `Content-Encoding: deflate` is zlib-wrapped(RFC 1950)
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	_, err := w.Write(originalBuf)
+	assert.Nil(err)
+	w.Close()
+
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.False(deflateDecoder.Validate(c))
+
+	c.SetRequestHeader(elton.HeaderContentEncoding, "deflate")
+	assert.True(deflateDecoder.Validate(c))
+	buf, err := deflateDecoder.Decode(c, b.Bytes())
+	assert.Nil(err)
+	assert.Equal(originalBuf, buf)
+}
+
+func TestDeflateDecoderRawFlateFallback(t *testing.T) {
+	assert := assert.New(t)
+	deflateDecoder := NewDeflateDecoder()
+	originalBuf := []byte("abcdabcdabcd")
+	// a minority of clients send raw DEFLATE(RFC 1951) despite the
+	// `deflate` coding name, which should still be accepted
+	var b bytes.Buffer
+	fw, _ := flate.NewWriter(&b, flate.BestCompression)
+	_, err := fw.Write(originalBuf)
+	assert.Nil(err)
+	fw.Close()
+
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	c.SetRequestHeader(elton.HeaderContentEncoding, "deflate")
+	buf, err := deflateDecoder.Decode(c, b.Bytes())
+	assert.Nil(err)
+	assert.Equal(originalBuf, buf)
+}
+
+func TestBrotliDecoder(t *testing.T) {
+	assert := assert.New(t)
+	brotliDecoder := NewBrotliDecoder()
+	originalBuf := []byte("abcdabcdabcd")
+	var b bytes.Buffer
+	w := brotli.NewWriter(&b)
+	_, err := w.Write(originalBuf)
+	assert.Nil(err)
+	w.Close()
+
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.False(brotliDecoder.Validate(c))
+
+	c.SetRequestHeader(elton.HeaderContentEncoding, "br")
+	assert.True(brotliDecoder.Validate(c))
+	buf, err := brotliDecoder.Decode(c, b.Bytes())
+	assert.Nil(err)
+	assert.Equal(originalBuf, buf)
+}
+
+func TestGzipDecoderDecompressedLimit(t *testing.T) {
+	assert := assert.New(t)
+	bomb := bytes.Repeat([]byte("a"), 10*1024*1024)
+	var b bytes.Buffer
+	w, _ := gzip.NewWriterLevel(&b, 9)
+	_, err := w.Write(bomb)
+	assert.Nil(err)
+	w.Close()
+	// a 10MB payload of a single repeated byte compresses down to roughly
+	// 10KB under gzip's ~1032:1 ceiling for DEFLATE, well under the 1MB
+	// DecompressedLimit below
+	assert.True(b.Len() < 11*1024)
+
+	conf := Config{
+		DecompressedLimit: 1024 * 1024,
+	}
+	conf.AddDecoder(NewGzipDecoder())
+	bodyParser := New(conf)
+
+	req := httptest.NewRequest("POST", "https://aslant.site/", bytes.NewReader(b.Bytes()))
+	req.Header.Set(elton.HeaderContentType, "application/json")
+	req.Header.Set(elton.HeaderContentEncoding, "gzip")
+	c := elton.NewContext(nil, req)
+	err = bodyParser(c)
+	assert.NotNil(err)
+	assert.Equal("statusCode=413, category=elton-body-parser, message=decompressed body is too large, it should be <= 1048576", err.Error())
+}
+
+func TestEncodingChainDecode(t *testing.T) {
+	assert := assert.New(t)
+	originalBuf := []byte(`{"name": "tree.xie"}`)
+
+	var gzipped bytes.Buffer
+	gw, _ := gzip.NewWriterLevel(&gzipped, 9)
+	_, err := gw.Write(originalBuf)
+	assert.Nil(err)
+	gw.Close()
+
+	var chained bytes.Buffer
+	bw := brotli.NewWriter(&chained)
+	_, err = bw.Write(gzipped.Bytes())
+	assert.Nil(err)
+	bw.Close()
+
+	conf := Config{}
+	conf.AddDecoder(NewGzipDecoder())
+	conf.AddDecoder(NewBrotliDecoder())
+	bodyParser := New(conf)
+
+	req := httptest.NewRequest("POST", "https://aslant.site/", bytes.NewReader(chained.Bytes()))
+	req.Header.Set(elton.HeaderContentType, "application/json")
+	req.Header.Set(elton.HeaderContentEncoding, "gzip, br")
+	c := elton.NewContext(nil, req)
+	done := false
+	c.Next = func() error {
+		done = true
+		if !bytes.Equal(c.RequestBody, originalBuf) {
+			return hes.New("request body is invalid")
+		}
+		return nil
+	}
+	err = bodyParser(c)
+	assert.Nil(err)
+	assert.True(done)
+}
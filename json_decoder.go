@@ -0,0 +1,179 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bodyparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/vicanso/elton"
+	"github.com/vicanso/hes"
+)
+
+const (
+	// disallowUnknownFieldsContextKey is the context key used to pass
+	// JSONDecoderOptions.DisallowUnknownFields down to BindBody, since the
+	// decoded value is kept as a map[string]interface{} and the option only
+	// takes effect once it's unmarshalled into the caller's struct
+	disallowUnknownFieldsContextKey = "bodyParserDisallowUnknownFields"
+)
+
+var (
+	errNotObjectOrArray = errors.New("top-level value must be an object or array")
+	errTrailingData     = errors.New("unexpected trailing data after the top-level value")
+)
+
+type (
+	// JSONDecoderOptions options for NewJSONDecoder
+	JSONDecoderOptions struct {
+		// UseNumber decodes numbers as json.Number instead of float64, to
+		// avoid losing precision on large integers
+		UseNumber bool
+		// DisallowUnknownFields causes the decoder to reject fields the
+		// caller's struct doesn't define, it only has an effect once the
+		// decoded value is later bound into a struct(eg: via BindBody)
+		DisallowUnknownFields bool
+		// AllowScalars allows a bare JSON scalar(eg: `true`, `123`, `"str"`)
+		// as the top-level value, RFC 8259 permits it but most APIs only
+		// expect an object or array. Defaults to false.
+		AllowScalars bool
+		// MaxDepth limits how deeply nested objects/arrays may be, 0 means
+		// no limit. Guards against pathological nesting.
+		MaxDepth int
+	}
+)
+
+// newInvalidJSONError wraps a json parse error as a hes.Error
+func newInvalidJSONError(err error) error {
+	return &hes.Error{
+		Category:   ErrCategory,
+		Message:    "invalid json format: " + err.Error(),
+		StatusCode: http.StatusBadRequest,
+		Err:        err,
+	}
+}
+
+// decodeJSONValue reads the next JSON value from decoder into native go
+// types(map[string]interface{}, []interface{} or a scalar), enforcing
+// maxDepth(0 means unlimited) along the way.
+func decodeJSONValue(decoder *json.Decoder, depth int, maxDepth int) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+	if maxDepth > 0 && depth > maxDepth {
+		return nil, &hes.Error{
+			Category:   ErrCategory,
+			Message:    "json exceeds max depth",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	switch delim {
+	case '{':
+		obj := make(map[string]interface{})
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := keyToken.(string)
+			value, err := decodeJSONValue(decoder, depth+1, maxDepth)
+			if err != nil {
+				return nil, err
+			}
+			obj[key] = value
+		}
+		// consume the closing '}'
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	case '[':
+		arr := make([]interface{}, 0)
+		for decoder.More() {
+			value, err := decodeJSONValue(decoder, depth+1, maxDepth)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		// consume the closing ']'
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return arr, nil
+	default:
+		return nil, nil
+	}
+}
+
+// NewJSONDecoder new json decoder, it streams the body through a
+// json.Decoder rather than sniffing the first/last byte, so it correctly
+// rejects malformed input like `{]` and (unless AllowScalars is set) bare
+// scalars, as well as trailing garbage or a second top-level value.
+func NewJSONDecoder(opts ...JSONDecoderOptions) *Decoder {
+	var options JSONDecoderOptions
+	if len(opts) != 0 {
+		options = opts[0]
+	}
+	return &Decoder{
+		Validate: func(c *elton.Context) bool {
+			ct := c.GetRequestHeader(elton.HeaderContentType)
+			ctFields := strings.Split(ct, ";")
+			return ctFields[0] == jsonContentType
+		},
+		Decode: func(c *elton.Context, originalData []byte) (data []byte, err error) {
+			trimmed := bytes.TrimSpace(originalData)
+			if len(trimmed) == 0 {
+				return nil, nil
+			}
+			decoder := json.NewDecoder(bytes.NewReader(trimmed))
+			if options.UseNumber {
+				decoder.UseNumber()
+			}
+			if options.DisallowUnknownFields {
+				decoder.DisallowUnknownFields()
+			}
+			value, e := decodeJSONValue(decoder, 1, options.MaxDepth)
+			if e != nil {
+				return nil, newInvalidJSONError(e)
+			}
+			if !options.AllowScalars {
+				switch value.(type) {
+				case map[string]interface{}, []interface{}:
+				default:
+					return nil, newInvalidJSONError(errNotObjectOrArray)
+				}
+			}
+			// 顶层值之后只能是EOF，否则表示还有额外的数据（如重复的顶层值或多余字符）
+			if _, e := decoder.Token(); e != io.EOF {
+				return nil, newInvalidJSONError(errTrailingData)
+			}
+			if options.DisallowUnknownFields {
+				c.Set(disallowUnknownFieldsContextKey, true)
+			}
+			c.Set(decodedBodyContextKey, value)
+			return originalData, nil
+		},
+	}
+}
@@ -0,0 +1,67 @@
+package bodyparser
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestJSONDecoder(t *testing.T) {
+	assert := assert.New(t)
+	jsonDecoder := NewJSONDecoder()
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.False(jsonDecoder.Validate(c))
+	c.SetRequestHeader(elton.HeaderContentType, elton.MIMEApplicationJSON)
+	assert.True(jsonDecoder.Validate(c))
+
+	buf := []byte(`{"a": 1}`)
+	data, err := jsonDecoder.Decode(c, buf)
+	assert.Nil(err)
+	assert.Equal(buf, data)
+	decoded, ok := c.Get(decodedBodyContextKey)
+	assert.True(ok)
+	assert.Equal(map[string]interface{}{"a": float64(1)}, decoded)
+
+	_, err = jsonDecoder.Decode(c, []byte("abcd"))
+	assert.NotNil(err)
+
+	_, err = jsonDecoder.Decode(c, []byte("{abcd"))
+	assert.NotNil(err)
+
+	// `[abcd]` used to be accepted by the old first/last byte sniffing,
+	// but it's rejected as invalid JSON under the new streaming decode
+	_, err = jsonDecoder.Decode(c, []byte("[abcd]"))
+	assert.NotNil(err)
+
+	// trailing garbage after a valid top-level value is rejected
+	_, err = jsonDecoder.Decode(c, []byte(`{"a": 1} {"b": 2}`))
+	assert.NotNil(err)
+
+	// nested object exceeding MaxDepth is rejected
+	deepDecoder := NewJSONDecoder(JSONDecoderOptions{MaxDepth: 2})
+	_, err = deepDecoder.Decode(c, []byte(`{"a": {"b": {"c": 1}}}`))
+	assert.NotNil(err)
+}
+
+func TestJSONDecoderAllowScalars(t *testing.T) {
+	assert := assert.New(t)
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	strict := NewJSONDecoder()
+	_, err := strict.Decode(c, []byte(`true`))
+	assert.NotNil(err)
+
+	lenient := NewJSONDecoder(JSONDecoderOptions{
+		AllowScalars: true,
+		UseNumber:    true,
+	})
+	data, err := lenient.Decode(c, []byte(`123`))
+	assert.Nil(err)
+	assert.Equal([]byte(`123`), data)
+	decoded, ok := c.Get(decodedBodyContextKey)
+	assert.True(ok)
+	assert.Equal(json.Number("123"), decoded)
+}
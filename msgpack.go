@@ -0,0 +1,45 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bodyparser
+
+import (
+	"net/http"
+
+	"github.com/vicanso/elton"
+	"github.com/vicanso/hes"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const msgpackContentType = "application/x-msgpack"
+
+// NewMsgpackDecoder creates a ContentTypeDecode for `application/x-msgpack`,
+// decoding the body into a map[string]interface{}.
+func NewMsgpackDecoder() ContentTypeDecode {
+	return func(c *elton.Context, originalData []byte) (data interface{}, err error) {
+		result := make(map[string]interface{})
+		e := msgpack.Unmarshal(originalData, &result)
+		if e != nil {
+			err = &hes.Error{
+				Category:   ErrCategory,
+				Message:    e.Error(),
+				StatusCode: http.StatusBadRequest,
+				Err:        e,
+			}
+			return
+		}
+		data = result
+		return
+	}
+}
@@ -0,0 +1,30 @@
+package bodyparser
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestMsgpackDecoder(t *testing.T) {
+	assert := assert.New(t)
+	msgpackDecoder := NewMsgpackDecoder()
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	buf, err := msgpack.Marshal(map[string]interface{}{
+		"name": "tree.xie",
+	})
+	assert.Nil(err)
+
+	data, err := msgpackDecoder(c, buf)
+	assert.Nil(err)
+	m, ok := data.(map[string]interface{})
+	assert.True(ok)
+	assert.Equal("tree.xie", m["name"])
+
+	_, err = msgpackDecoder(c, []byte("abcd"))
+	assert.NotNil(err)
+}
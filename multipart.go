@@ -0,0 +1,108 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bodyparser
+
+import (
+	"bytes"
+	"mime"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/vicanso/elton"
+	"github.com/vicanso/hes"
+)
+
+const (
+	multipartFormContentType = "multipart/form-data"
+	// defaultMultipartMaxMemory is the default threshold(in bytes) above
+	// which uploaded files are spilled to disk, matching net/http's default
+	defaultMultipartMaxMemory = 32 << 20
+	// filesContextKey is the context key used to store the files parsed by
+	// NewMultipartFormDecoder, retrieved by RequestFiles
+	filesContextKey = "bodyParserRequestFiles"
+)
+
+type (
+	// MultipartConfig multipart/form-data decoder config
+	MultipartConfig struct {
+		// MaxMemory is the maximum amount of the body kept in memory,
+		// the remainder is stored on disk in temporary files(see
+		// mime/multipart.Reader.ReadForm). Defaults to 32MB.
+		MaxMemory int64
+	}
+)
+
+// NewMultipartFormDecoder creates a ContentTypeDecode for
+// `multipart/form-data`, decoding the form values into a
+// map[string]interface{} and keeping the uploaded files accessible via
+// RequestFiles.
+func NewMultipartFormDecoder(config MultipartConfig) ContentTypeDecode {
+	maxMemory := config.MaxMemory
+	if maxMemory == 0 {
+		maxMemory = defaultMultipartMaxMemory
+	}
+	return func(c *elton.Context, originalData []byte) (data interface{}, err error) {
+		_, params, e := mime.ParseMediaType(c.GetRequestHeader(elton.HeaderContentType))
+		if e != nil {
+			err = &hes.Error{
+				Category:   ErrCategory,
+				Message:    e.Error(),
+				StatusCode: http.StatusBadRequest,
+				Err:        e,
+			}
+			return
+		}
+		boundary := params["boundary"]
+		if boundary == "" {
+			err = &hes.Error{
+				Category:   ErrCategory,
+				Message:    "no multipart boundary param in Content-Type",
+				StatusCode: http.StatusBadRequest,
+			}
+			return
+		}
+		form, e := multipart.NewReader(bytes.NewReader(originalData), boundary).ReadForm(maxMemory)
+		if e != nil {
+			err = &hes.Error{
+				Category:   ErrCategory,
+				Message:    e.Error(),
+				StatusCode: http.StatusBadRequest,
+				Err:        e,
+			}
+			return
+		}
+		c.Set(filesContextKey, form.File)
+
+		result := make(map[string]interface{})
+		for key, values := range form.Value {
+			if len(values) == 1 {
+				result[key] = values[0]
+				continue
+			}
+			result[key] = values
+		}
+		data = result
+		return
+	}
+}
+
+// RequestFiles returns the uploaded files parsed by a multipart/form-data
+// decoder, keyed by the form field name. It returns nil if the request body
+// was not decoded as multipart/form-data.
+func RequestFiles(c *elton.Context) map[string][]*multipart.FileHeader {
+	v, _ := c.Get(filesContextKey)
+	files, _ := v.(map[string][]*multipart.FileHeader)
+	return files
+}
@@ -0,0 +1,40 @@
+package bodyparser
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestMultipartFormDecoder(t *testing.T) {
+	assert := assert.New(t)
+
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	err := w.WriteField("name", "tree.xie")
+	assert.Nil(err)
+	fw, err := w.CreateFormFile("file", "test.txt")
+	assert.Nil(err)
+	_, err = fw.Write([]byte("hello"))
+	assert.Nil(err)
+	assert.Nil(w.Close())
+
+	req := httptest.NewRequest("POST", "https://aslant.site/", nil)
+	req.Header.Set(elton.HeaderContentType, w.FormDataContentType())
+	c := elton.NewContext(httptest.NewRecorder(), req)
+
+	multipartDecoder := NewMultipartFormDecoder(MultipartConfig{})
+	data, err := multipartDecoder(c, b.Bytes())
+	assert.Nil(err)
+	m, ok := data.(map[string]interface{})
+	assert.True(ok)
+	assert.Equal("tree.xie", m["name"])
+
+	files := RequestFiles(c)
+	assert.Equal(1, len(files["file"]))
+	assert.Equal("test.txt", files["file"][0].Filename)
+}
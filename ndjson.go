@@ -0,0 +1,181 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bodyparser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/vicanso/elton"
+	"github.com/vicanso/hes"
+)
+
+const (
+	ndjsonContentType      = "application/x-ndjson"
+	ndjsonAltContentType   = "application/jsonl"
+	ndjsonIteratorKey      = "bodyParserNDJSONIterator"
+	ndjsonBatchIteratorKey = "bodyParserNDJSONBatchIterator"
+)
+
+type (
+	// NDJSONIterate yields one decoded record at a time, stopping as soon as
+	// yield returns false or the stream is exhausted/errors
+	NDJSONIterate func(yield func(raw json.RawMessage, err error) bool)
+	// NDJSONBatchIterate yields fixed-size(at most) batches of records
+	NDJSONBatchIterate func(yield func(batch []json.RawMessage, err error) bool)
+	// NDJSONConfig config for NewNDJSONDecoder/NewNDJSONBatchDecoder
+	NDJSONConfig struct {
+		// Limit the limit size of the request body
+		Limit int
+		// MaxLineSize the max size of a single line(record), defaults to
+		// bufio.MaxScanTokenSize
+		MaxLineSize int
+		Skipper     elton.Skipper
+	}
+)
+
+// isNDJSON checks the request's content type(ignoring `;charset=...`) is
+// application/x-ndjson or application/jsonl
+func isNDJSON(c *elton.Context) bool {
+	ct := getContentType(c)
+	return ct == ndjsonContentType || ct == ndjsonAltContentType
+}
+
+// newNDJSONScanner prepares a bufio.Scanner over the(optionally size
+// limited) request body, splitting on lines
+func newNDJSONScanner(c *elton.Context, config NDJSONConfig) (*bufio.Scanner, func() error) {
+	r := c.Request.Body
+	if config.Limit > 0 {
+		r = MaxBytesReader(r, int64(config.Limit))
+	}
+	scanner := bufio.NewScanner(r)
+	if config.MaxLineSize > 0 {
+		// bufio.Scanner accepts tokens up to the larger of max and the
+		// initial buffer's capacity, so the buffer must not exceed MaxLineSize
+		initialSize := 64 * 1024
+		if config.MaxLineSize < initialSize {
+			initialSize = config.MaxLineSize
+		}
+		scanner.Buffer(make([]byte, 0, initialSize), config.MaxLineSize)
+	}
+	return scanner, r.Close
+}
+
+// ndjsonScanError wraps a scanner error as a hes.Error
+func ndjsonScanError(err error) error {
+	return &hes.Error{
+		Category:   ErrCategory,
+		Message:    err.Error(),
+		StatusCode: http.StatusBadRequest,
+		Err:        err,
+	}
+}
+
+// NewNDJSONDecoder creates a middleware which, for `application/x-ndjson`
+// (or `application/jsonl`) requests, stores a streaming NDJSONIterate on
+// the context instead of eagerly reading the whole body, so a handler can
+// process one record at a time via NDJSONIterator(c) without loading the
+// whole payload into memory.
+func NewNDJSONDecoder(config NDJSONConfig) elton.Handler {
+	skipper := config.Skipper
+	if skipper == nil {
+		skipper = elton.DefaultSkipper
+	}
+	return func(c *elton.Context) (err error) {
+		if skipper(c) || !isNDJSON(c) {
+			return c.Next()
+		}
+		scanner, closeBody := newNDJSONScanner(c, config)
+		c.Set(ndjsonIteratorKey, NDJSONIterate(func(yield func(raw json.RawMessage, err error) bool) {
+			defer closeBody()
+			for scanner.Scan() {
+				line := scanner.Bytes()
+				if len(bytes.TrimSpace(line)) == 0 {
+					continue
+				}
+				raw := make(json.RawMessage, len(line))
+				copy(raw, line)
+				if !yield(raw, nil) {
+					return
+				}
+			}
+			if e := scanner.Err(); e != nil {
+				yield(nil, ndjsonScanError(e))
+			}
+		}))
+		return c.Next()
+	}
+}
+
+// NewNDJSONBatchDecoder is the same as NewNDJSONDecoder, but collects
+// records into batches of at most batchSize, for handlers preferring batch
+// semantics. The iterator is retrieved via NDJSONBatchIterator(c).
+func NewNDJSONBatchDecoder(batchSize int, config NDJSONConfig) elton.Handler {
+	skipper := config.Skipper
+	if skipper == nil {
+		skipper = elton.DefaultSkipper
+	}
+	return func(c *elton.Context) (err error) {
+		if skipper(c) || !isNDJSON(c) {
+			return c.Next()
+		}
+		scanner, closeBody := newNDJSONScanner(c, config)
+		c.Set(ndjsonBatchIteratorKey, NDJSONBatchIterate(func(yield func(batch []json.RawMessage, err error) bool) {
+			defer closeBody()
+			batch := make([]json.RawMessage, 0, batchSize)
+			for scanner.Scan() {
+				line := scanner.Bytes()
+				if len(bytes.TrimSpace(line)) == 0 {
+					continue
+				}
+				raw := make(json.RawMessage, len(line))
+				copy(raw, line)
+				batch = append(batch, raw)
+				if len(batch) == batchSize {
+					if !yield(batch, nil) {
+						return
+					}
+					batch = make([]json.RawMessage, 0, batchSize)
+				}
+			}
+			if e := scanner.Err(); e != nil {
+				yield(nil, ndjsonScanError(e))
+				return
+			}
+			if len(batch) != 0 {
+				yield(batch, nil)
+			}
+		}))
+		return c.Next()
+	}
+}
+
+// NDJSONIterator returns the streaming iterator stored by NewNDJSONDecoder,
+// or nil if the request wasn't decoded as ndjson.
+func NDJSONIterator(c *elton.Context) NDJSONIterate {
+	v, _ := c.Get(ndjsonIteratorKey)
+	fn, _ := v.(NDJSONIterate)
+	return fn
+}
+
+// NDJSONBatchIterator returns the streaming batch iterator stored by
+// NewNDJSONBatchDecoder, or nil if the request wasn't decoded as ndjson.
+func NDJSONBatchIterator(c *elton.Context) NDJSONBatchIterate {
+	v, _ := c.Get(ndjsonBatchIteratorKey)
+	fn, _ := v.(NDJSONBatchIterate)
+	return fn
+}
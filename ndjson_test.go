@@ -0,0 +1,68 @@
+package bodyparser
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestNDJSONDecoder(t *testing.T) {
+	assert := assert.New(t)
+	ndjsonDecoder := NewNDJSONDecoder(NDJSONConfig{})
+
+	body := "{\"name\":\"tree.xie\"}\n{\"name\":\"vicanso\"}\n"
+	req := httptest.NewRequest("POST", "https://aslant.site/", strings.NewReader(body))
+	req.Header.Set(elton.HeaderContentType, ndjsonContentType)
+	c := elton.NewContext(nil, req)
+	done := false
+	c.Next = func() error {
+		done = true
+		names := make([]string, 0)
+		NDJSONIterator(c)(func(raw json.RawMessage, err error) bool {
+			assert.Nil(err)
+			m := make(map[string]string)
+			if e := json.Unmarshal(raw, &m); e != nil {
+				return false
+			}
+			names = append(names, m["name"])
+			return true
+		})
+		assert.Equal([]string{"tree.xie", "vicanso"}, names)
+		return nil
+	}
+	err := ndjsonDecoder(c)
+	assert.Nil(err)
+	assert.True(done)
+}
+
+func TestNDJSONBatchDecoder(t *testing.T) {
+	assert := assert.New(t)
+	ndjsonDecoder := NewNDJSONBatchDecoder(2, NDJSONConfig{})
+
+	body := "{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n"
+	req := httptest.NewRequest("POST", "https://aslant.site/", strings.NewReader(body))
+	req.Header.Set(elton.HeaderContentType, "application/jsonl")
+	c := elton.NewContext(nil, req)
+	done := false
+	c.Next = func() error {
+		done = true
+		batches := 0
+		total := 0
+		NDJSONBatchIterator(c)(func(batch []json.RawMessage, err error) bool {
+			assert.Nil(err)
+			batches++
+			total += len(batch)
+			return true
+		})
+		assert.Equal(2, batches)
+		assert.Equal(3, total)
+		return nil
+	}
+	err := ndjsonDecoder(c)
+	assert.Nil(err)
+	assert.True(done)
+}
@@ -0,0 +1,59 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bodyparser
+
+import (
+	"net/http"
+
+	"github.com/vicanso/elton"
+	"github.com/vicanso/hes"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// NewJSONSchemaValidator creates a Validator which validates the decoded
+// body against the given JSON schema(as a string), rejecting the request
+// with a 400 hes.Error whose Extra map is keyed by field path and holds the
+// description of the violated constraint. A field has one entry, so of
+// several failures on the same field only the last is kept.
+func NewJSONSchemaValidator(schema string) Validator {
+	schemaLoader := gojsonschema.NewStringLoader(schema)
+	return func(c *elton.Context, decoded []byte) error {
+		if len(decoded) == 0 {
+			return nil
+		}
+		result, err := gojsonschema.Validate(schemaLoader, gojsonschema.NewBytesLoader(decoded))
+		if err != nil {
+			return &hes.Error{
+				Category:   ErrCategory,
+				Message:    err.Error(),
+				StatusCode: http.StatusBadRequest,
+				Err:        err,
+			}
+		}
+		if result.Valid() {
+			return nil
+		}
+		extra := make(map[string]interface{})
+		for _, re := range result.Errors() {
+			extra[re.Field()] = re.Description()
+		}
+		return &hes.Error{
+			Category:   ErrCategory,
+			Message:    "request body validation failed",
+			StatusCode: http.StatusBadRequest,
+			Extra:      extra,
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package bodyparser
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestJSONSchemaValidator(t *testing.T) {
+	assert := assert.New(t)
+	schema := `{
+		"type": "object",
+		"required": ["name"],
+		"properties": {
+			"name": {"type": "string"}
+		}
+	}`
+	validator := NewJSONSchemaValidator(schema)
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	assert.Nil(validator(c, []byte(`{"name": "tree.xie"}`)))
+
+	err := validator(c, []byte(`{"age": 18}`))
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "elton-body-parser")
+}
+
+func TestBodyParserValidator(t *testing.T) {
+	assert := assert.New(t)
+	conf := Config{
+		Validator: NewJSONSchemaValidator(`{
+			"type": "object",
+			"required": ["name"]
+		}`),
+	}
+	conf.AddDecoder(NewJSONDecoder())
+	bodyParser := New(conf)
+
+	body := `{"age": 18}`
+	req := httptest.NewRequest("POST", "https://aslant.site/", strings.NewReader(body))
+	req.Header.Set(elton.HeaderContentType, "application/json")
+	c := elton.NewContext(nil, req)
+	err := bodyParser(c)
+	assert.NotNil(err)
+}
@@ -0,0 +1,92 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bodyparser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+
+	"github.com/vicanso/elton"
+	"github.com/vicanso/hes"
+)
+
+const (
+	xmlContentType     = "application/xml"
+	textXMLContentType = "text/xml"
+)
+
+// NewXMLDecoder creates a ContentTypeDecode for `application/xml` and
+// `text/xml`, decoding the body into a map[string]interface{} so that it
+// can be re-used by BindBody without a schema known ahead of time.
+func NewXMLDecoder() ContentTypeDecode {
+	return func(c *elton.Context, originalData []byte) (data interface{}, err error) {
+		decoder := xml.NewDecoder(bytes.NewReader(originalData))
+		root, e := decodeXMLElement(decoder)
+		if e != nil {
+			err = &hes.Error{
+				Category:   ErrCategory,
+				Message:    e.Error(),
+				StatusCode: http.StatusBadRequest,
+				Err:        e,
+			}
+			return
+		}
+		data = root
+		return
+	}
+}
+
+// decodeXMLElement reads tokens from decoder up to the EndElement closing the
+// current element(or the end of input at the top level) and converts them to
+// a map[string]interface{}, with child elements keyed by tag name and text
+// content stored under "#text". At the top level the root element itself is
+// the single key of the returned map.
+func decodeXMLElement(decoder *xml.Decoder) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if len(result) == 0 {
+				return nil, err
+			}
+			return result, nil
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := decodeXMLElement(decoder)
+			if err != nil {
+				return nil, err
+			}
+			if exist, ok := result[t.Name.Local]; ok {
+				switch v := exist.(type) {
+				case []interface{}:
+					result[t.Name.Local] = append(v, child)
+				default:
+					result[t.Name.Local] = []interface{}{v, child}
+				}
+			} else {
+				result[t.Name.Local] = child
+			}
+		case xml.CharData:
+			text := bytes.TrimSpace(t.Copy())
+			if len(text) != 0 {
+				result["#text"] = string(text)
+			}
+		case xml.EndElement:
+			return result, nil
+		}
+	}
+}
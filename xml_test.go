@@ -0,0 +1,28 @@
+package bodyparser
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestXMLDecoder(t *testing.T) {
+	assert := assert.New(t)
+	xmlDecoder := NewXMLDecoder()
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	data, err := xmlDecoder(c, []byte(`<user><name>tree.xie</name><age>18</age></user>`))
+	assert.Nil(err)
+	root, ok := data.(map[string]interface{})
+	assert.True(ok)
+	user, ok := root["user"].(map[string]interface{})
+	assert.True(ok)
+	name, ok := user["name"].(map[string]interface{})
+	assert.True(ok)
+	assert.Equal("tree.xie", name["#text"])
+
+	_, err = xmlDecoder(c, []byte(`<user>`))
+	assert.NotNil(err)
+}